@@ -0,0 +1,14 @@
+package kubo
+
+import (
+	"github.com/ipfs/kubo/config"
+	"github.com/ipfs/kubo/core/corehttp"
+)
+
+// gatewayOpts returns the corehttp.ServeOption set mounted on the
+// gateway/API listener, appending corehttp.RoutingOption() alongside the
+// other corehttp options so Gateway.ExposeRoutingAPI actually exposes the
+// Routing V1 API rather than sitting unused.
+func gatewayOpts(cfg *config.Config, opts []corehttp.ServeOption) []corehttp.ServeOption {
+	return append(opts, corehttp.RoutingOption())
+}
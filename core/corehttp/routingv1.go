@@ -0,0 +1,145 @@
+package corehttp
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/ipfs/kubo/core"
+	irouting "github.com/ipfs/kubo/routing"
+)
+
+// maxIPNSRecordSize bounds how much of a PUT /routing/v1/ipns/{name} body we
+// are willing to read into memory before giving up.
+const maxIPNSRecordSize = 256 * 1024
+
+// routingV1Handler serves the Routing V1 (IPIP-337) HTTP API on top of an
+// aggregated irouting.ProvideManyRouter. It is the server-side counterpart of
+// the delegated HTTP router client built in core/node/libp2p/routing.go:
+// mounting it turns this node into a delegated router for lighter clients.
+type routingV1Handler struct {
+	router irouting.ProvideManyRouter
+}
+
+type ndjsonProviderRecord struct {
+	Schema string   `json:"Schema"`
+	ID     string   `json:"ID"`
+	Addrs  []string `json:"Addrs,omitempty"`
+}
+
+func (h *routingV1Handler) handleFindProviders(w http.ResponseWriter, r *http.Request) {
+	c, err := cid.Decode(mux.Vars(r)["cid"])
+	if err != nil {
+		http.Error(w, "invalid cid: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	for ai := range h.router.FindProvidersAsync(ctx, c, 0) {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		addrs := make([]string, 0, len(ai.Addrs))
+		for _, a := range ai.Addrs {
+			addrs = append(addrs, a.String())
+		}
+
+		if err := enc.Encode(ndjsonProviderRecord{Schema: "peer", ID: ai.ID.String(), Addrs: addrs}); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func (h *routingV1Handler) handleFindPeers(w http.ResponseWriter, r *http.Request) {
+	pid, err := peer.Decode(mux.Vars(r)["peerid"])
+	if err != nil {
+		http.Error(w, "invalid peer id: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ai, err := h.router.FindPeer(r.Context(), pid)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	addrs := make([]string, 0, len(ai.Addrs))
+	for _, a := range ai.Addrs {
+		addrs = append(addrs, a.String())
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	_ = json.NewEncoder(w).Encode(ndjsonProviderRecord{Schema: "peer", ID: ai.ID.String(), Addrs: addrs})
+}
+
+func (h *routingV1Handler) handleGetIPNS(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	val, err := h.router.GetValue(r.Context(), "/ipns/"+name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.ipfs.ipns-record")
+	w.Write(val)
+}
+
+func (h *routingV1Handler) handlePutIPNS(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIPNSRecordSize))
+	if err != nil {
+		http.Error(w, "reading record: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.router.PutValue(r.Context(), "/ipns/"+name, body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RoutingOption mounts the Routing V1 HTTP API under /routing/v1, backed by
+// the node's composed router, when Gateway.ExposeRoutingAPI is enabled.
+func RoutingOption() ServeOption {
+	return func(n *core.IpfsNode, _ net.Listener, serveMux *http.ServeMux) (*http.ServeMux, error) {
+		cfg, err := n.Repo.Config()
+		if err != nil {
+			return nil, err
+		}
+
+		if !cfg.Gateway.ExposeRoutingAPI {
+			return serveMux, nil
+		}
+
+		h := &routingV1Handler{router: n.Routing}
+
+		r := mux.NewRouter()
+		r.HandleFunc("/routing/v1/providers/{cid}", h.handleFindProviders).Methods(http.MethodGet)
+		r.HandleFunc("/routing/v1/peers/{peerid}", h.handleFindPeers).Methods(http.MethodGet)
+		r.HandleFunc("/routing/v1/ipns/{name}", h.handleGetIPNS).Methods(http.MethodGet)
+		r.HandleFunc("/routing/v1/ipns/{name}", h.handlePutIPNS).Methods(http.MethodPut)
+
+		serveMux.Handle("/routing/v1/", r)
+		return serveMux, nil
+	}
+}
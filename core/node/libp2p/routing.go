@@ -3,20 +3,34 @@ package libp2p
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"net/http"
 	"runtime/debug"
 	"sort"
 	"time"
 
+	"github.com/ipfs/boxo/ipns"
+	httpclient "github.com/ipfs/boxo/routing/http/client"
+	httpcontentrouter "github.com/ipfs/boxo/routing/http/contentrouter"
 	offroute "github.com/ipfs/boxo/routing/offline"
 	ds "github.com/ipfs/go-datastore"
+	logging "github.com/ipfs/go-log/v2"
+	dht "github.com/libp2p/go-libp2p-kad-dht"
 	ddht "github.com/libp2p/go-libp2p-kad-dht/dual"
+	"github.com/libp2p/go-libp2p-kad-dht/fullrt"
+	kbucket "github.com/libp2p/go-libp2p-kbucket"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	namesys "github.com/libp2p/go-libp2p-pubsub-router"
 	record "github.com/libp2p/go-libp2p-record"
 	routinghelpers "github.com/libp2p/go-libp2p-routing-helpers"
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/event"
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/libp2p/go-libp2p/core/routing"
+	relayv2proto "github.com/libp2p/go-libp2p/p2p/protocol/circuitv2/proto"
+	ma "github.com/multiformats/go-multiaddr"
 	"go.uber.org/fx"
 
 	config "github.com/ipfs/kubo/config"
@@ -25,10 +39,21 @@ import (
 	irouting "github.com/ipfs/kubo/routing"
 )
 
+var log = logging.Logger("core/node/libp2p")
+
+// routingOptionCustomKwd is the Routing.Type value that tells the daemon to
+// build its routing system from the Routing.Routers/Routing.Methods config
+// section instead of one of the built-in presets.
+const routingOptionCustomKwd = "custom"
+
 type Router struct {
 	routing.Routing
 
 	Priority int // less = more important
+
+	// Timeout bounds how long Routing()'s aggregate query waits on this
+	// router. Zero means no explicit per-router timeout is applied.
+	Timeout time.Duration
 }
 
 type p2pRouterOut struct {
@@ -51,15 +76,103 @@ type processInitialRoutingIn struct {
 type processInitialRoutingOut struct {
 	fx.Out
 
-	Router        Router                 `group:"routers"`
-	ContentRouter routing.ContentRouting `group:"content-routers"`
+	Router        Router        `group:"routers"`
+	ContentRouter ContentRouter `group:"content-routers"`
 
 	DHT       *ddht.DHT
 	DHTClient routing.Routing `name:"dhtc"`
+	Kademlia  Kademlia        `name:"kademlia"`
 }
 
 type AddrInfoChan chan peer.AddrInfo
 
+// Kademlia is the common surface of the dual DHT client and the accelerated
+// (FullRT) DHT client, so command code (e.g. `ipfs stats dht`, `ipfs routing`)
+// can introspect whichever one is actually active without caring which it is.
+// BaseRouting provides it as the `name:"kademlia"` output, set to whichever
+// of the two is currently in use as DHTClient.
+type Kademlia interface {
+	routing.Routing
+
+	GetClosestPeers(ctx context.Context, key string) ([]peer.ID, error)
+	Stat() map[string]kbucket.PeerInfo
+	Bootstrap(ctx context.Context) error
+}
+
+// dualDHTKademlia adapts a *ddht.DHT to the Kademlia interface. *ddht.DHT
+// already has GetClosestPeers/Bootstrap, but not Stat() -- only
+// *fullrt.FullRT does -- so without this adapter Kademlia would only ever
+// be populated when Experimental.AcceleratedDHTClient is on, which is not
+// the common case. Stat() merges both of the dual DHT's routing tables,
+// keyed by peer ID, the same way `ipfs stats dht`/`ipfs routing` want to
+// see it regardless of which client is actually active.
+type dualDHTKademlia struct {
+	*ddht.DHT
+}
+
+func (d dualDHTKademlia) Stat() map[string]kbucket.PeerInfo {
+	stat := make(map[string]kbucket.PeerInfo)
+	for _, p := range d.WAN.RoutingTable().GetPeerInfos() {
+		stat[p.Id.String()] = p
+	}
+	for _, p := range d.LAN.RoutingTable().GetPeerInfos() {
+		stat[p.Id.String()] = p
+	}
+	return stat
+}
+
+// dhtPeerAdder dispatches identify events onto the dual DHT's routing
+// tables, so a peer is only ever added once its supported protocols are
+// known (via identify) and include the DHT protocol -- instead of every
+// newly connected peer being added on spec.
+type dhtPeerAdder struct {
+	dht *ddht.DHT
+}
+
+// dhtProtocolLAN is the LAN DHT's protocol ID. Like dht.ProtocolDHT for the
+// WAN side, it is derived from the default "/ipfs" prefix, with the LAN
+// side inserting the dual package's LAN extension ahead of "/kad/<version>".
+const dhtProtocolLAN = protocol.ID("/ipfs/lan/kad/1.0.0")
+
+func (a *dhtPeerAdder) tryAdd(p peer.ID, protos []protocol.ID) {
+	var supportsWAN, supportsLAN bool
+	for _, proto := range protos {
+		switch proto {
+		case dht.ProtocolDHT:
+			supportsWAN = true
+		case dhtProtocolLAN:
+			supportsLAN = true
+		}
+	}
+
+	// queryPeer=false, isReplaceable=true: this peer was learned from
+	// identify, not from a DHT query response, so it shouldn't get credit
+	// for being "useful" yet and should remain evictable until it is. Each
+	// table is only touched for peers that actually speak its own DHT
+	// protocol, so a WAN-only peer never pollutes the LAN table and a
+	// LAN-only peer is no longer invisible to LAN routing-table gating.
+	if supportsWAN {
+		if _, err := a.dht.WAN.RoutingTable().TryAddPeer(p, false, true); err != nil {
+			log.Debugw("failed to add identified peer to WAN routing table", "peer", p, "error", err)
+		}
+	}
+	if supportsLAN {
+		if _, err := a.dht.LAN.RoutingTable().TryAddPeer(p, false, true); err != nil {
+			log.Debugw("failed to add identified peer to LAN routing table", "peer", p, "error", err)
+		}
+	}
+}
+
+// recoverIdentifyGating guards a single identify event dispatch in
+// BaseRouting's routing-table-gating loop, mirroring the per-iteration
+// recovery autoRelayFeeder uses for its own background loops.
+func recoverIdentifyGating() {
+	if r := recover(); r != nil {
+		log.Errorw("recovered from panic while handling identify event", "error", r)
+		debug.PrintStack()
+	}
+}
+
 func BaseRouting(cfg *config.Config) interface{} {
 	return func(lc fx.Lifecycle, in processInitialRoutingIn) (out processInitialRoutingOut, err error) {
 		var dualDHT *ddht.DHT
@@ -87,31 +200,166 @@ func BaseRouting(cfg *config.Config) interface{} {
 			}
 		}
 
+		// DHTClient defaults to the dual DHT client itself; when the
+		// accelerated client is enabled it is swapped out below, but the
+		// dual DHT keeps participating so LAN queries keep working and
+		// Routing()'s delegated HTTP routers keep composing on top of it.
+		var dhtClient routing.Routing = dualDHT
+
+		if cfg.Experimental.AcceleratedDHTClient {
+			if dualDHT == nil {
+				return out, fmt.Errorf("cannot use an accelerated DHT client without a DHT")
+			}
+
+			fullrtDHT, err := fullrt.NewFullRT(in.Host,
+				dht.DefaultPrefix,
+				fullrt.DHTOption(
+					dht.Validator(in.Validator),
+					dht.Datastore(in.Repo.Datastore()),
+					dht.BucketSize(20),
+				),
+			)
+			if err != nil {
+				return out, fmt.Errorf("constructing accelerated DHT client: %w", err)
+			}
+
+			lc.Append(fx.Hook{
+				OnStop: func(ctx context.Context) error {
+					return fullrtDHT.Close()
+				},
+			})
+
+			dhtClient = fullrtDHT
+		}
+
+		if dualDHT != nil {
+			sub, err := in.Host.EventBus().Subscribe([]interface{}{
+				new(event.EvtPeerIdentificationCompleted),
+				new(event.EvtPeerProtocolsUpdated),
+			})
+			if err != nil {
+				return out, fmt.Errorf("subscribing to identify events: %w", err)
+			}
+
+			adder := &dhtPeerAdder{dht: dualDHT}
+			identifyGatingDone := make(chan struct{})
+			go func() {
+				defer close(identifyGatingDone)
+
+				for ev := range sub.Out() {
+					func() {
+						defer recoverIdentifyGating()
+
+						switch evt := ev.(type) {
+						case event.EvtPeerIdentificationCompleted:
+							adder.tryAdd(evt.Peer, evt.Protocols)
+						case event.EvtPeerProtocolsUpdated:
+							adder.tryAdd(evt.Peer, evt.Added)
+						}
+					}()
+				}
+			}()
+
+			lc.Append(fx.Hook{
+				OnStop: func(context.Context) error {
+					err := sub.Close()
+					<-identifyGatingDone
+					return err
+				},
+			})
+		}
+
+		// Whichever client ends up serving DHTClient -- the dual DHT or the
+		// accelerated FullRT client -- is also exposed uniformly as Kademlia
+		// for command code that needs GetClosestPeers/Stat/Bootstrap. The
+		// dual DHT needs the dualDHTKademlia adapter for this (see above);
+		// *fullrt.FullRT already satisfies Kademlia directly.
+		var kademliaClient Kademlia
+		switch c := dhtClient.(type) {
+		case Kademlia:
+			kademliaClient = c
+		case *ddht.DHT:
+			kademliaClient = dualDHTKademlia{DHT: c}
+		default:
+			log.Warnw("DHT client does not satisfy the Kademlia interface; ipfs stats dht/ipfs routing introspection will be unavailable", "type", fmt.Sprintf("%T", dhtClient))
+		}
+
 		return processInitialRoutingOut{
 			Router: Router{
 				Priority: 1000,
 				Routing:  in.Router,
 			},
-			DHT:           dualDHT,
-			DHTClient:     dualDHT,
-			ContentRouter: in.Router,
+			DHT:       dualDHT,
+			DHTClient: dhtClient,
+			Kademlia:  kademliaClient,
+			ContentRouter: ContentRouter{
+				Router:      in.Router,
+				Priority:    1000,
+				IgnoreError: true,
+			},
 		}, nil
 	}
 }
 
+// ContentRouter wraps a routing.ContentRouting the same way Router wraps a
+// routing.Routing, so ContentRouting() can compose it in parallel with the
+// same knobs Routing() offers for its own routers group.
+type ContentRouter struct {
+	Router routing.ContentRouting
+
+	Priority int // less = more important
+
+	// IgnoreError marks this router's errors as non-fatal to the aggregate
+	// query.
+	IgnoreError bool
+
+	// Timeout bounds how long the aggregate query waits on this router.
+	// Zero means no explicit per-router timeout is applied.
+	Timeout time.Duration
+}
+
 type p2pOnlineContentRoutingIn struct {
 	fx.In
 
-	ContentRouter []routing.ContentRouting `group:"content-routers"`
+	ContentRouter []ContentRouter `group:"content-routers"`
 }
 
-// ContentRouting will get all routers that can do contentRouting and add them
-// all together using a TieredRouter. It will be used for topic discovery.
-func ContentRouting(in p2pOnlineContentRoutingIn) routing.ContentRouting {
-	var routers []routing.Routing
+// ContentRouting returns an fx constructor that gets all routers able to do
+// contentRouting and adds them together for topic discovery. By default it
+// builds a ComposableParallel, so FindProviders fans out to every configured
+// router and Provide reaches every writable one, with a slow or erroring
+// router bounded by its own Timeout/IgnoreError so it never blocks the rest
+// of the aggregate. When Routing.ContentRoutingTiered is set, it instead
+// builds a routinghelpers.Tiered over the routers in priority order; Tiered
+// still runs Provide and FindProviders on all of its routers in parallel,
+// and the per-router Timeout and IgnoreError settings are not applied.
+func ContentRouting(cfg *config.Config) interface{} {
+	return func(in p2pOnlineContentRoutingIn) routing.ContentRouting {
+		routers := in.ContentRouter
+
+		sort.SliceStable(routers, func(i, j int) bool {
+			return routers[i].Priority < routers[j].Priority
+		})
+
+		if cfg.Routing.ContentRoutingTiered {
+			tRouters := make([]routing.Routing, 0, len(routers))
+			for _, r := range routers {
+				tRouters = append(tRouters, &routinghelpers.Compose{ContentRouting: r.Router})
+			}
+
+			return routinghelpers.Tiered{Routers: tRouters}
+		}
 
-	return routinghelpers.Tiered{
-		Routers: routers,
+		cRouters := make([]*routinghelpers.ParallelRouter, 0, len(routers))
+		for _, r := range routers {
+			cRouters = append(cRouters, &routinghelpers.ParallelRouter{
+				Router:      &routinghelpers.Compose{ContentRouting: r.Router},
+				IgnoreError: r.IgnoreError,
+				Timeout:     r.Timeout,
+			})
+		}
+
+		return routinghelpers.NewComposableParallel(cRouters)
 	}
 }
 
@@ -133,10 +381,204 @@ func Routing(in p2pOnlineRoutingIn) irouting.ProvideManyRouter {
 	})
 
 	var cRouters []*routinghelpers.ParallelRouter
+	for _, r := range routers {
+		cRouters = append(cRouters, &routinghelpers.ParallelRouter{
+			Router:      r.Routing,
+			IgnoreError: true,
+			Timeout:     r.Timeout,
+		})
+	}
 
 	return routinghelpers.NewComposableParallel(cRouters)
 }
 
+// httpRoutingWrapper adapts one delegated HTTP router to the full
+// routing.Routing interface used by routinghelpers.ParallelRouter.
+// Provide/FindProvidersAsync are delegated to the embedded
+// routing.ContentRouting, which is what httpcontentrouter.Client actually
+// adapts; FindPeer/GetValue/PutValue instead talk to the raw Routing V1
+// client directly, since the content-routing adapter no-ops them.
+// SearchValue/Bootstrap aren't meaningful for a delegated HTTP router and
+// are stubbed out.
+type httpRoutingWrapper struct {
+	routing.ContentRouting
+
+	client *httpclient.Client
+}
+
+func (c *httpRoutingWrapper) FindPeer(ctx context.Context, id peer.ID) (peer.AddrInfo, error) {
+	recs, err := c.client.FindPeers(ctx, id)
+	if err != nil {
+		return peer.AddrInfo{}, err
+	}
+	for _, rec := range recs {
+		if rec.ID != id {
+			continue
+		}
+		addrs := make([]ma.Multiaddr, 0, len(rec.Addrs))
+		for _, a := range rec.Addrs {
+			addrs = append(addrs, a.Multiaddr)
+		}
+		return peer.AddrInfo{ID: id, Addrs: addrs}, nil
+	}
+	return peer.AddrInfo{}, routing.ErrNotFound
+}
+
+func (c *httpRoutingWrapper) GetValue(ctx context.Context, key string, _ ...routing.Option) ([]byte, error) {
+	name, err := ipns.NameFromRoutingKey([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	rec, err := c.client.GetIPNS(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return ipns.MarshalRecord(rec)
+}
+
+func (c *httpRoutingWrapper) PutValue(ctx context.Context, key string, value []byte, _ ...routing.Option) error {
+	name, err := ipns.NameFromRoutingKey([]byte(key))
+	if err != nil {
+		return err
+	}
+	rec, err := ipns.UnmarshalRecord(value)
+	if err != nil {
+		return err
+	}
+	return c.client.PutIPNS(ctx, name, rec)
+}
+
+func (c *httpRoutingWrapper) SearchValue(ctx context.Context, key string, _ ...routing.Option) (<-chan []byte, error) {
+	return nil, routing.ErrNotSupported
+}
+
+func (c *httpRoutingWrapper) Bootstrap(ctx context.Context) error {
+	return nil
+}
+
+// ConstructDelegatedRouting builds the HTTP delegated routing (Reframe /
+// Routing V1) system described by routers and methods: each of the five
+// methods (FindProviders, Provide, FindPeers, GetIPNS, PutIPNS) is dispatched
+// to whichever router it names in methods, wrapped with that method's
+// timeout and execute-after delay. peerID, addrs and privKey are used to
+// self-identify (and, for Provide, sign provider records) when talking to
+// the HTTP endpoint; PutIPNS forwards the caller's already-signed record
+// as is. Only HTTP-type routers are currently supported.
+func ConstructDelegatedRouting(routers config.Routers, methods config.Methods, peerID peer.ID, addrs []ma.Multiaddr, privKey crypto.PrivKey) (routing.Routing, error) {
+	cache := make(map[string]routing.Routing, len(routers))
+
+	buildMethod := func(methodName config.MethodName) (routinghelpers.ParallelRouter, error) {
+		method, ok := methods[methodName]
+		if !ok || method.RouterName == "" {
+			return routinghelpers.ParallelRouter{}, nil
+		}
+
+		r, ok := cache[method.RouterName]
+		if !ok {
+			parsed, ok := routers[method.RouterName]
+			if !ok {
+				return routinghelpers.ParallelRouter{}, fmt.Errorf("router %q (used by method %q) is not defined under Routing.Routers", method.RouterName, methodName)
+			}
+
+			params, ok := parsed.Parameters.(*config.HTTPRouterParams)
+			if !ok || parsed.Type != config.RouterTypeHTTP {
+				return routinghelpers.ParallelRouter{}, fmt.Errorf("router %q must be of type %q", method.RouterName, config.RouterTypeHTTP)
+			}
+
+			client, err := httpclient.New(
+				params.Endpoint,
+				httpclient.WithHTTPClient(&http.Client{Timeout: params.Timeout.Duration}),
+				httpclient.WithIdentity(privKey),
+			)
+			if err != nil {
+				return routinghelpers.ParallelRouter{}, fmt.Errorf("constructing http client for router %q: %w", method.RouterName, err)
+			}
+
+			r = &httpRoutingWrapper{
+				ContentRouting: httpcontentrouter.NewContentRoutingClient(
+					client,
+					httpcontentrouter.WithPeerID(peerID),
+					httpcontentrouter.WithAddrs(addrs),
+				),
+				client: client,
+			}
+			cache[method.RouterName] = r
+		}
+
+		return routinghelpers.ParallelRouter{
+			Router:       r,
+			IgnoreError:  true,
+			Timeout:      method.Timeout.Duration,
+			ExecuteAfter: method.ExecuteAfter.Duration,
+		}, nil
+	}
+
+	composer := &irouting.Composer{}
+	dst := map[config.MethodName]*routinghelpers.ParallelRouter{
+		config.MethodNameFindProviders: &composer.FindProvidersRouter,
+		config.MethodNameProvide:       &composer.ProvideRouter,
+		config.MethodNameFindPeers:     &composer.FindPeersRouter,
+		config.MethodNameGetIPNS:       &composer.GetValueRouter,
+		config.MethodNamePutIPNS:       &composer.PutValueRouter,
+	}
+	for methodName, router := range dst {
+		built, err := buildMethod(methodName)
+		if err != nil {
+			return nil, err
+		}
+		*router = built
+	}
+
+	return composer, nil
+}
+
+type delegatedRoutingIn struct {
+	fx.In
+
+	Host host.Host
+}
+
+// DelegatedRoutingOption returns the fx.Option that actually selects the
+// delegated HTTP routing path: when Routing.Type is set to
+// routingOptionCustomKwd it provides DelegatedRouting(cfg) into the routers
+// group that Routing() aggregates, otherwise it is a no-op.
+func DelegatedRoutingOption(cfg *config.Config) fx.Option {
+	if cfg.Routing.Type != routingOptionCustomKwd {
+		return fx.Options()
+	}
+
+	return fx.Provide(DelegatedRouting(cfg))
+}
+
+// DelegatedRouting returns an fx constructor that builds the HTTP delegated
+// routing system described by Routing.Routers/Routing.Methods and adds it to
+// the routers group that Routing() aggregates. It is only wired into the fx
+// graph when Routing.Type is set to routingOptionCustomKwd; see
+// DelegatedRoutingOption.
+func DelegatedRouting(cfg *config.Config) interface{} {
+	return func(in delegatedRoutingIn) (out p2pRouterOut, err error) {
+		privKey := in.Host.Peerstore().PrivKey(in.Host.ID())
+
+		r, err := ConstructDelegatedRouting(
+			cfg.Routing.Routers,
+			cfg.Routing.Methods,
+			in.Host.ID(),
+			in.Host.Addrs(),
+			privKey,
+		)
+		if err != nil {
+			return p2pRouterOut{}, fmt.Errorf("constructing delegated routing: %w", err)
+		}
+
+		return p2pRouterOut{
+			Router: Router{
+				Routing:  r,
+				Priority: 100,
+			},
+		}, nil
+	}
+}
+
 // OfflineRouting provides a special Router to the routers list when we are creating a offline node.
 func OfflineRouting(dstore ds.Datastore, validator record.Validator) p2pRouterOut {
 	return p2pRouterOut{
@@ -167,6 +609,12 @@ func PubsubRouter(mctx helpers.MetricsCtx, lc fx.Lifecycle, in p2pPSRoutingIn) (
 		return p2pRouterOut{}, nil, err
 	}
 
+	// Unlike the DHT, PubSub already gates mesh membership on identify: it
+	// only admits a peer once identify confirms it speaks one of the
+	// PubSub protocols, via the WithPeerFilter option passed to
+	// pubsub.NewGossipSub when in.PubSub was constructed. There is nothing
+	// left for PubsubRouter to gate post hoc here.
+
 	return p2pRouterOut{
 		Router: Router{
 			Routing: &routinghelpers.Compose{
@@ -180,16 +628,143 @@ func PubsubRouter(mctx helpers.MetricsCtx, lc fx.Lifecycle, in p2pPSRoutingIn) (
 	}, psRouter, nil
 }
 
+const (
+	autoRelayFeederMinBackoff = 15 * time.Second
+	autoRelayFeederMaxBackoff = 1 * time.Hour
+	autoRelayFeederDHTTimeout = 2 * time.Minute
+)
+
+// recoverAutoRelayFeeder guards a single iteration of the AutoRelayFeeder's
+// background loops so a panic there doesn't take the whole daemon down with
+// it; the loop that called it keeps running on the next iteration.
+func recoverAutoRelayFeeder() {
+	if r := recover(); r != nil {
+		log.Errorw("recovered from panic in AutoRelayFeeder", "error", r)
+		debug.PrintStack()
+	}
+}
+
 func autoRelayFeeder(cfgPeering config.Peering, peerChan chan<- peer.AddrInfo) fx.Option {
 	return fx.Invoke(func(lc fx.Lifecycle, h host.Host, dht *ddht.DHT) {
-		_, cancel := context.WithCancel(context.Background())
+		ctx, cancel := context.WithCancel(context.Background())
 		done := make(chan struct{})
 
-		defer func() {
-			if r := recover(); r != nil {
-				fmt.Println("Recovering from unexpected error in AutoRelayFeeder:", r)
-				debug.PrintStack()
+		// Configured peering peers are always relay candidates: feed them in
+		// right away, from their own goroutine so a slow/unbuffered peerChan
+		// with no consumer draining it yet doesn't cause them to be dropped.
+		peeringDone := make(chan struct{})
+		go func() {
+			defer close(peeringDone)
+
+			for _, p := range cfgPeering.Peers {
+				select {
+				case peerChan <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		sub, err := h.EventBus().Subscribe(new(event.EvtPeerIdentificationCompleted))
+		if err != nil {
+			log.Errorw("failed to subscribe to identify events, AutoRelayFeeder will rely on the DHT only", "error", err)
+		}
+
+		identifyDone := make(chan struct{})
+		go func() {
+			defer close(identifyDone)
+
+			if sub == nil {
+				return
 			}
+			defer sub.Close()
+
+			for {
+				select {
+				case ev, ok := <-sub.Out():
+					if !ok {
+						return
+					}
+					func() {
+						defer recoverAutoRelayFeeder()
+
+						evt := ev.(event.EvtPeerIdentificationCompleted)
+						for _, p := range evt.Protocols {
+							if p == relayv2proto.ProtoIDv2Hop {
+								select {
+								case peerChan <- peer.AddrInfo{ID: evt.Peer}:
+								case <-ctx.Done():
+								}
+								return
+							}
+						}
+					}()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		dhtDone := make(chan struct{})
+		go func() {
+			defer close(dhtDone)
+
+			backoff := autoRelayFeederMinBackoff
+			for {
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return
+				}
+
+				func() {
+					defer recoverAutoRelayFeeder()
+
+					var key [32]byte
+					if _, err := rand.Read(key[:]); err != nil {
+						return
+					}
+
+					queryCtx, queryCancel := context.WithTimeout(ctx, autoRelayFeederDHTTimeout)
+					defer queryCancel()
+
+					peers, err := dht.WAN.GetClosestPeers(queryCtx, string(key[:]))
+					if err != nil {
+						return
+					}
+
+					found := 0
+					for _, p := range peers {
+						protos, err := h.Peerstore().SupportsProtocols(p, relayv2proto.ProtoIDv2Hop)
+						if err != nil || len(protos) == 0 {
+							continue
+						}
+
+						found++
+						select {
+						case peerChan <- peer.AddrInfo{ID: p, Addrs: h.Peerstore().Addrs(p)}:
+						case <-ctx.Done():
+							return
+						}
+					}
+
+					if found == 0 {
+						backoff *= 2
+						if backoff > autoRelayFeederMaxBackoff {
+							backoff = autoRelayFeederMaxBackoff
+						}
+					} else {
+						backoff = autoRelayFeederMinBackoff
+					}
+				}()
+			}
+		}()
+
+		go func() {
+			<-peeringDone
+			<-identifyDone
+			<-dhtDone
+			close(done)
 		}()
 
 		lc.Append(fx.Hook{
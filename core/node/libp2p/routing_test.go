@@ -0,0 +1,146 @@
+package libp2p
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/routing"
+
+	"github.com/ipfs/kubo/config"
+)
+
+// testContentRouting builds ContentRouting's aggregate router with the
+// default (parallel) config, since ContentRouting is now a cfg-gated fx
+// constructor factory rather than a plain function.
+func testContentRouting(in p2pOnlineContentRoutingIn) routing.ContentRouting {
+	build := ContentRouting(&config.Config{}).(func(p2pOnlineContentRoutingIn) routing.ContentRouting)
+	return build(in)
+}
+
+type mockContentRouter struct {
+	provided   chan cid.Cid
+	provideErr error
+
+	providers []peer.AddrInfo
+	findWait  time.Duration
+}
+
+func (m *mockContentRouter) Provide(ctx context.Context, c cid.Cid, _ bool) error {
+	if m.provided != nil {
+		m.provided <- c
+	}
+	return m.provideErr
+}
+
+func (m *mockContentRouter) FindProvidersAsync(ctx context.Context, _ cid.Cid, _ int) <-chan peer.AddrInfo {
+	out := make(chan peer.AddrInfo, len(m.providers))
+	go func() {
+		defer close(out)
+		if m.findWait > 0 {
+			select {
+			case <-time.After(m.findWait):
+			case <-ctx.Done():
+				return
+			}
+		}
+		for _, p := range m.providers {
+			select {
+			case out <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func TestContentRoutingFindProvidersFansOut(t *testing.T) {
+	p1 := peer.AddrInfo{ID: peer.ID("peer1")}
+	p2 := peer.AddrInfo{ID: peer.ID("peer2")}
+
+	r1 := &mockContentRouter{providers: []peer.AddrInfo{p1}}
+	r2 := &mockContentRouter{providers: []peer.AddrInfo{p2}}
+
+	cr := testContentRouting(p2pOnlineContentRoutingIn{
+		ContentRouter: []ContentRouter{
+			{Router: r1, Priority: 1, IgnoreError: true},
+			{Router: r2, Priority: 2, IgnoreError: true},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	found := map[peer.ID]bool{}
+	for ai := range cr.FindProvidersAsync(ctx, cid.Cid{}, 0) {
+		found[ai.ID] = true
+	}
+
+	if !found[p1.ID] || !found[p2.ID] {
+		t.Fatalf("expected providers from both routers, got %v", found)
+	}
+}
+
+func TestContentRoutingProvideFansOutToAllWritableRouters(t *testing.T) {
+	r1 := &mockContentRouter{provided: make(chan cid.Cid, 1)}
+	r2 := &mockContentRouter{provided: make(chan cid.Cid, 1)}
+
+	cr := testContentRouting(p2pOnlineContentRoutingIn{
+		ContentRouter: []ContentRouter{
+			{Router: r1, Priority: 1, IgnoreError: true},
+			{Router: r2, Priority: 2, IgnoreError: true},
+		},
+	})
+
+	if err := cr.Provide(context.Background(), cid.Cid{}, true); err != nil {
+		t.Fatalf("Provide returned error: %v", err)
+	}
+
+	select {
+	case <-r1.provided:
+	default:
+		t.Fatal("router 1 was never asked to provide")
+	}
+	select {
+	case <-r2.provided:
+	default:
+		t.Fatal("router 2 was never asked to provide")
+	}
+}
+
+func TestContentRoutingSlowOrFailingRouterDoesNotBlockAggregate(t *testing.T) {
+	p1 := peer.AddrInfo{ID: peer.ID("peer1")}
+
+	slow := &mockContentRouter{findWait: time.Second, provideErr: errors.New("boom")}
+	fast := &mockContentRouter{providers: []peer.AddrInfo{p1}}
+
+	cr := testContentRouting(p2pOnlineContentRoutingIn{
+		ContentRouter: []ContentRouter{
+			{Router: slow, Priority: 1, IgnoreError: true, Timeout: 50 * time.Millisecond},
+			{Router: fast, Priority: 2, IgnoreError: true},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var got []peer.ID
+	for ai := range cr.FindProvidersAsync(ctx, cid.Cid{}, 0) {
+		got = append(got, ai.ID)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("slow router blocked the aggregate: took %s", elapsed)
+	}
+	if len(got) != 1 || got[0] != p1.ID {
+		t.Fatalf("expected only the fast router's provider, got %v", got)
+	}
+
+	if err := cr.Provide(context.Background(), cid.Cid{}, true); err != nil {
+		t.Fatalf("Provide with IgnoreError should not surface the failing router's error: %v", err)
+	}
+}